@@ -0,0 +1,473 @@
+package structs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// hostnameLabelRegex matches a single valid DNS label, i.e. one
+// dot-delimited segment of a hostname.
+var hostnameLabelRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+
+// IngressGatewayConfigEntry manages the configuration for an ingress service
+// with the given name.
+type IngressGatewayConfigEntry struct {
+	// Kind of the config entry. This should be set to api.IngressGateway.
+	Kind string
+
+	// Name is used to match the config entry with its associated ingress
+	// gateway service. This should match the name provided in the service
+	// definition.
+	Name string
+
+	// Listeners declares what ports the ingress gateway should listen on,
+	// and what services to associated to those ports.
+	Listeners []IngressListener
+
+	// TLS holds the gateway-wide TLS configuration. Listeners that don't
+	// declare their own TLS block inherit this one.
+	TLS IngressGatewayTLSConfig
+
+	Meta map[string]string `json:",omitempty"`
+
+	// CreateIndex is the Raft index this entry was created at. This is a
+	// read-only field.
+	CreateIndex uint64
+
+	// ModifyIndex is used for the Check-And-Set operations and can also be
+	// fed back into the WriteRequest options. This is a read-only field.
+	ModifyIndex uint64
+}
+
+// IngressListener manages the configuration for a listener on a specific
+// port.
+type IngressListener struct {
+	// Port declares the port on which the ingress gateway should listen for
+	// traffic.
+	Port int
+
+	// Protocol declares what type of traffic this listener is expected to
+	// receive. Depending on the protocol, a listener might support
+	// multiplexing several services over a single port, or additional
+	// discovery chain features. The current supported values are:
+	// "tcp", "http", "http2" and "grpc".
+	Protocol string
+
+	// Services declares the set of services to which the listener forwards
+	// traffic.
+	//
+	// For "tcp" protocol listeners, only a single service is allowed, unless
+	// the listener's effective TLS config is enabled and sets SNI.
+	// For HTTP-like listeners ("http", "http2", "grpc"), multiple services
+	// can be declared, and the wildcard specifier "*" is also permitted.
+	Services []IngressService
+
+	// TLS, if set, overrides the gateway-wide TLS settings for this
+	// listener only. If unset, the listener inherits the gateway's TLS
+	// config.
+	TLS *IngressGatewayTLSConfig `json:",omitempty"`
+}
+
+// IngressGatewayTLSConfig describes how a listener should terminate TLS.
+// agent/xds/tls_ingress.go translates this into the DownstreamTlsContext
+// and SNI-based FilterChainMatch Envoy uses to terminate TLS on the
+// listener.
+type IngressGatewayTLSConfig struct {
+	// Enabled indicates whether TLS is enabled for this listener. When set
+	// with no certificate material, the listener is intended to present a
+	// leaf certificate issued by the Connect CA, but the xDS ingress
+	// listener builder doesn't yet implement that path; see
+	// agent/xds/tls_ingress.go.
+	Enabled bool
+
+	// Cert and Key are an inline PEM-encoded certificate and private key to
+	// terminate TLS with. Both must be set together, and cannot be combined
+	// with CertFile/KeyFile.
+	Cert string `json:",omitempty"`
+	Key  string `json:",omitempty"`
+
+	// CertFile and KeyFile reference a certificate and private key made
+	// available to the gateway on disk, or through a secrets backend
+	// exposed as a file path. Both must be set together, and cannot be
+	// combined with Cert/Key.
+	CertFile string `json:",omitempty"`
+	KeyFile  string `json:",omitempty"`
+
+	// SNI restricts which server names may negotiate TLS on this listener.
+	// This is required on a "tcp" listener that multiplexes more than one
+	// service over the same port.
+	SNI []string `json:",omitempty"`
+}
+
+// IngressService manages the configuration for a service that is exposed on
+// an ingress gateway listener.
+type IngressService struct {
+	// Name declares the service to which traffic should be forwarded.
+	//
+	// This can be a specific service, or the wildcard specifier, "*". If this
+	// is set to "*", then all services will be exposed over the same
+	// listener. This is only valid for a listener with an HTTP-like protocol.
+	Name string
+
+	// Namespace is the namespace where the service is located.
+	// Namespacing is a Consul Enterprise feature.
+	Namespace string `json:",omitempty"`
+
+	// Hosts is a list of hostnames which should be associated to this
+	// service on the defined listener. Only allowed on layer 7 protocols,
+	// this allows a listener to be able to route traffic to multiple
+	// services using a "virtual host" approach.
+	//
+	// If a service is exposed on multiple listeners of the same gateway, its
+	// hosts must be unique across each listener. Host comparisons for
+	// uniqueness are case-insensitive, matching DNS/:authority semantics.
+	// These are translated into RouteConfiguration virtual host domains in
+	// agent/xds/routes_ingress.go so Envoy dispatches by :authority/Host
+	// header.
+	Hosts []string `json:",omitempty"`
+
+	// Routes allows a single HTTP-like listener to fan requests for this
+	// service out to one or more upstream services based on the URL of the
+	// incoming request. Only allowed on layer 7 protocols.
+	Routes []IngressServiceRoute `json:",omitempty"`
+}
+
+// IngressServiceRoute associates a Match condition with the upstream service
+// that matching requests should be forwarded to. These are translated into
+// Envoy routes, ordered ahead of the service's catch-all route, in
+// agent/xds/routes_ingress.go.
+type IngressServiceRoute struct {
+	// Name is the name of the upstream service that requests matching Match
+	// should be routed to.
+	Name string
+
+	// Namespace is the namespace of the upstream service.
+	// Namespacing is a Consul Enterprise feature.
+	Namespace string `json:",omitempty"`
+
+	// Match declares the conditions under which this route applies. At
+	// least one of PathExact, PathPrefix, PathRegex, Header or Methods must
+	// be set.
+	Match IngressServiceRouteMatch
+}
+
+// IngressServiceRouteMatch describes the conditions under which a request
+// matches an IngressServiceRoute. At most one of PathExact, PathPrefix or
+// PathRegex may be set, along with zero or more Header and Methods matchers.
+type IngressServiceRouteMatch struct {
+	// PathExact matches the entire path of a request.
+	PathExact string `json:",omitempty"`
+
+	// PathPrefix matches the beginning of the path of a request.
+	PathPrefix string `json:",omitempty"`
+
+	// PathRegex matches the path of a request against a regular expression.
+	PathRegex string `json:",omitempty"`
+
+	// Header is a set of header matchers that must all match for the route
+	// to apply.
+	Header []IngressServiceRouteHeader `json:",omitempty"`
+
+	// Methods is a list of HTTP methods for which this route applies. If
+	// unspecified, all methods match.
+	Methods []string `json:",omitempty"`
+}
+
+// IngressServiceRouteHeader matches a request header against an exact
+// value, prefix, suffix or regular expression, or simply checks for its
+// presence.
+type IngressServiceRouteHeader struct {
+	// Name is the name of the header to match.
+	Name string
+
+	// Present matches if the header is present with any value, unless
+	// Invert is set, in which case it matches if the header is absent.
+	Present bool `json:",omitempty"`
+
+	// Exact matches if the header value is equal to this value.
+	Exact string `json:",omitempty"`
+
+	// Prefix matches if the header value starts with this value.
+	Prefix string `json:",omitempty"`
+
+	// Suffix matches if the header value ends with this value.
+	Suffix string `json:",omitempty"`
+
+	// Regex matches if the header value matches this regular expression.
+	Regex string `json:",omitempty"`
+
+	// Invert inverts the match result for this header.
+	Invert bool `json:",omitempty"`
+}
+
+// IsHTTP returns true if the protocol for the listener is a variant of HTTP
+// (http, http2 or grpc). Protocols in this family support multiplexing
+// multiple services on the same port, as well as the wildcard specifier, "*".
+func (l IngressListener) IsHTTP() bool {
+	return IsProtocolHTTPLike(l.Protocol)
+}
+
+// IsProtocolHTTPLike returns true if the given protocol is treated as an
+// HTTP-family protocol for the purposes of ingress gateway listener
+// multiplexing, i.e. "http", "http2" or "grpc". The xDS listener/filter
+// translation that picks the matching Envoy codec lives in
+// agent/xds/listeners_ingress.go.
+func IsProtocolHTTPLike(protocol string) bool {
+	switch protocol {
+	case "http", "http2", "grpc":
+		return true
+	default:
+		return false
+	}
+}
+
+func (e *IngressGatewayConfigEntry) GetKind() string {
+	return IngressGateway
+}
+
+func (e *IngressGatewayConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+	return e.Name
+}
+
+func (e *IngressGatewayConfigEntry) Normalize() error {
+	if e == nil {
+		return nil
+	}
+
+	e.Kind = IngressGateway
+	for i, listener := range e.Listeners {
+		listener.Protocol = strings.ToLower(listener.Protocol)
+		if listener.Protocol == "" {
+			listener.Protocol = "tcp"
+		}
+
+		e.Listeners[i] = listener
+	}
+
+	return nil
+}
+
+func (e *IngressGatewayConfigEntry) Validate() error {
+	if err := validateIngressTLS(e.TLS); err != nil {
+		return fmt.Errorf("gateway TLS config is invalid: %v", err)
+	}
+
+	listenerPorts := make(map[int]bool)
+	declaredHosts := make(map[string]bool)
+	for _, listener := range e.Listeners {
+		if listenerPorts[listener.Port] {
+			return fmt.Errorf("port %d declared on two listeners", listener.Port)
+		}
+		listenerPorts[listener.Port] = true
+
+		if listener.Protocol != "tcp" && !IsProtocolHTTPLike(listener.Protocol) {
+			return fmt.Errorf("protocol must be 'tcp', 'http', 'http2' or 'grpc'. '%s' is an unsupported protocol", listener.Protocol)
+		}
+
+		if len(listener.Services) == 0 {
+			return fmt.Errorf("no service declared for listener with port %d", listener.Port)
+		}
+
+		if listener.TLS != nil {
+			if err := validateIngressTLS(*listener.TLS); err != nil {
+				return fmt.Errorf("TLS config for listener on port %d is invalid: %v", listener.Port, err)
+			}
+		}
+
+		if !listener.IsHTTP() && len(listener.Services) > 1 {
+			tlsCfg := e.effectiveTLS(listener)
+			if !tlsCfg.Enabled || len(tlsCfg.SNI) == 0 {
+				return fmt.Errorf("multiple services per listener are only supported for protocol 'http', 'http2' or 'grpc', or a 'tcp' listener with TLS SNI configured (listener on port %d)", listener.Port)
+			}
+		}
+
+		for _, s := range listener.Services {
+			if s.Name == "" {
+				return fmt.Errorf("Service name cannot be blank (listener on port %d)", listener.Port)
+			}
+
+			if s.Name == WildcardSpecifier && !listener.IsHTTP() {
+				return fmt.Errorf("Wildcard service name is only valid for protocol 'http', 'http2' or 'grpc' (listener on port %d)", listener.Port)
+			}
+
+			if len(s.Hosts) > 0 {
+				if !listener.IsHTTP() {
+					return fmt.Errorf("Associating hosts to a service is only supported for protocol 'http', 'http2' or 'grpc' (listener on port %d)", listener.Port)
+				}
+				if s.Name == WildcardSpecifier {
+					return fmt.Errorf("Associating hosts to a service is not supported when using a wildcard service name (listener on port %d)", listener.Port)
+				}
+			}
+
+			for _, h := range s.Hosts {
+				if err := validateIngressHost(h); err != nil {
+					return fmt.Errorf("Host %q for service %q is not valid: %v (listener on port %d)", h, s.Name, err, listener.Port)
+				}
+				key := strings.ToLower(h)
+				if declaredHosts[key] {
+					return fmt.Errorf("Host %q is declared on more than one listener", h)
+				}
+				declaredHosts[key] = true
+			}
+
+			if len(s.Routes) > 0 && !listener.IsHTTP() {
+				return fmt.Errorf("routes are only supported for protocol 'http', 'http2' or 'grpc' (listener on port %d)", listener.Port)
+			}
+		}
+
+		declaredRoutes := make(map[string]bool)
+		for _, s := range listener.Services {
+			for _, route := range s.Routes {
+				m := route.Match
+				if m.PathExact == "" && m.PathPrefix == "" && m.PathRegex == "" && len(m.Header) == 0 && len(m.Methods) == 0 {
+					return fmt.Errorf("route for service %q must set at least one match condition (listener on port %d)", route.Name, listener.Port)
+				}
+				if (m.PathExact != "" && m.PathPrefix != "") || (m.PathExact != "" && m.PathRegex != "") || (m.PathPrefix != "" && m.PathRegex != "") {
+					return fmt.Errorf("route for service %q sets more than one of PathExact, PathPrefix or PathRegex (listener on port %d)", route.Name, listener.Port)
+				}
+
+				for _, h := range m.Header {
+					if h.Name == "" {
+						return fmt.Errorf("route for service %q has a header match with no Name set (listener on port %d)", route.Name, listener.Port)
+					}
+					modes := 0
+					for _, set := range []bool{h.Present, h.Exact != "", h.Prefix != "", h.Suffix != "", h.Regex != ""} {
+						if set {
+							modes++
+						}
+					}
+					if modes == 0 {
+						return fmt.Errorf("route for service %q has a header match for %q that doesn't set Present, Exact, Prefix, Suffix or Regex (listener on port %d)", route.Name, h.Name, listener.Port)
+					}
+					if modes > 1 {
+						return fmt.Errorf("route for service %q has a header match for %q that sets more than one of Present, Exact, Prefix, Suffix or Regex (listener on port %d)", route.Name, h.Name, listener.Port)
+					}
+				}
+
+				if m.PathRegex != "" {
+					if _, err := regexp.Compile(m.PathRegex); err != nil {
+						return fmt.Errorf("route for service %q has an invalid PathRegex %q: %v (listener on port %d)", route.Name, m.PathRegex, err, listener.Port)
+					}
+				}
+
+				key := fmt.Sprintf("%#v", m)
+				if declaredRoutes[key] {
+					return fmt.Errorf("two routes with identical match criteria are declared on listener with port %d", listener.Port)
+				}
+				declaredRoutes[key] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// effectiveTLS returns the TLS config that applies to listener, falling
+// back to the gateway-wide default when the listener doesn't declare its
+// own.
+func (e *IngressGatewayConfigEntry) effectiveTLS(listener IngressListener) IngressGatewayTLSConfig {
+	if listener.TLS != nil {
+		return *listener.TLS
+	}
+	return e.TLS
+}
+
+// TLSConfig returns the TLS config that applies to listener, falling back
+// to the gateway-wide default when the listener doesn't declare its own.
+// This is the exported form of effectiveTLS for use by the xDS listener
+// builder in agent/xds/tls_ingress.go.
+func (e *IngressGatewayConfigEntry) TLSConfig(listener IngressListener) IngressGatewayTLSConfig {
+	return e.effectiveTLS(listener)
+}
+
+// validateIngressTLS checks that a TLS config's certificate material is
+// internally consistent: inline Cert/Key and file-referenced CertFile/KeyFile
+// are mutually exclusive, each pair must be set together, and inline
+// material must parse as a valid x509 key pair.
+func validateIngressTLS(cfg IngressGatewayTLSConfig) error {
+	hasInline := cfg.Cert != "" || cfg.Key != ""
+	hasFile := cfg.CertFile != "" || cfg.KeyFile != ""
+
+	if hasInline && hasFile {
+		return fmt.Errorf("cannot mix inline Cert/Key with a CertFile/KeyFile reference")
+	}
+	if (cfg.Cert == "") != (cfg.Key == "") {
+		return fmt.Errorf("Cert and Key must both be set, or both be empty")
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return fmt.Errorf("CertFile and KeyFile must both be set, or both be empty")
+	}
+
+	if hasInline {
+		if _, err := tls.X509KeyPair([]byte(cfg.Cert), []byte(cfg.Key)); err != nil {
+			return fmt.Errorf("Cert/Key is not a valid x509 key pair: %v", err)
+		}
+	}
+
+	for _, sni := range cfg.SNI {
+		if err := validateIngressHost(sni); err != nil {
+			return fmt.Errorf("SNI %q is not valid: %v", sni, err)
+		}
+	}
+
+	return nil
+}
+
+// validateIngressHost verifies that host is either a valid DNS name, or a
+// DNS name prefixed with a single wildcard label, i.e. "*.example.com".
+func validateIngressHost(host string) error {
+	if host == "" {
+		return fmt.Errorf("host cannot be empty")
+	}
+
+	name := host
+	if strings.HasPrefix(host, "*.") {
+		name = host[len("*."):]
+	} else if strings.Contains(host, "*") {
+		return fmt.Errorf("a wildcard specifier is only allowed as the leftmost label, i.e. '*.example.com'")
+	}
+
+	if len(name) > 255 {
+		return fmt.Errorf("name is longer than 255 characters")
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if !hostnameLabelRegex.MatchString(label) {
+			return fmt.Errorf("%q is not a valid DNS hostname", host)
+		}
+	}
+
+	return nil
+}
+
+// ContainsService checks if this config entry is for a service. This is
+// different from the Matches function since the service name is a shared
+// field across many config entry types.
+func (e *IngressGatewayConfigEntry) ContainsService(sid ServiceID) bool {
+	if e == nil {
+		return false
+	}
+
+	for _, listener := range e.Listeners {
+		for _, service := range listener.Services {
+			if service.Name == WildcardSpecifier {
+				return true
+			}
+			if NewServiceID(service.Name, nil).Matches(sid) {
+				return true
+			}
+			for _, route := range service.Routes {
+				if NewServiceID(route.Name, nil).Matches(sid) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,160 @@
+package xds
+
+import (
+	"fmt"
+
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_tcp_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	"github.com/golang/protobuf/ptypes"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// ingressListenerName returns the Envoy listener name for a given ingress
+// gateway listener port.
+func ingressListenerName(port int) string {
+	return fmt.Sprintf("ingress_upstream_%d", port)
+}
+
+// ingressRouteConfigName returns the name of the RouteConfiguration an
+// HTTP-like ingress listener fetches over RDS.
+func ingressRouteConfigName(port int) string {
+	return fmt.Sprintf("ingress_upstream_%d", port)
+}
+
+// makeIngressGatewayListeners builds one Envoy listener per IngressListener
+// configured on entry, bound to bindAddr.
+func makeIngressGatewayListeners(bindAddr string, entry *structs.IngressGatewayConfigEntry) ([]*envoy_listener_v3.Listener, error) {
+	listeners := make([]*envoy_listener_v3.Listener, 0, len(entry.Listeners))
+	for _, l := range entry.Listeners {
+		listener, err := makeIngressGatewayListener(bindAddr, entry, l)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// makeIngressGatewayListener builds the Envoy listener for a single
+// configured IngressListener, selecting the filter chain appropriate to its
+// protocol and terminating TLS on it if enabled.
+func makeIngressGatewayListener(bindAddr string, entry *structs.IngressGatewayConfigEntry, l structs.IngressListener) (*envoy_listener_v3.Listener, error) {
+	filter, err := makeIngressListenerFilter(l)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := &envoy_listener_v3.FilterChain{
+		Filters: []*envoy_listener_v3.Filter{filter},
+	}
+
+	if err := applyIngressTLS(chain, entry, l); err != nil {
+		return nil, err
+	}
+
+	return &envoy_listener_v3.Listener{
+		Name:         ingressListenerName(l.Port),
+		Address:      makeSocketAddress(bindAddr, l.Port),
+		FilterChains: []*envoy_listener_v3.FilterChain{chain},
+	}, nil
+}
+
+func makeSocketAddress(addr string, port int) *envoy_core_v3.Address {
+	return &envoy_core_v3.Address{
+		Address: &envoy_core_v3.Address_SocketAddress{
+			SocketAddress: &envoy_core_v3.SocketAddress{
+				Address: addr,
+				PortSpecifier: &envoy_core_v3.SocketAddress_PortValue{
+					PortValue: uint32(port),
+				},
+			},
+		},
+	}
+}
+
+func makeIngressListenerFilter(l structs.IngressListener) (*envoy_listener_v3.Filter, error) {
+	if l.IsHTTP() {
+		return makeIngressHTTPFilter(l)
+	}
+	if l.Protocol == "tcp" {
+		return makeIngressTCPFilter(l)
+	}
+	return nil, fmt.Errorf("no listener filter defined for protocol %q", l.Protocol)
+}
+
+// makeIngressTCPFilter builds a tcp_proxy filter for a plain tcp listener,
+// forwarding to its first configured service. A tcp listener with TLS SNI
+// may declare several services; all of its traffic still goes to the first.
+func makeIngressTCPFilter(l structs.IngressListener) (*envoy_listener_v3.Filter, error) {
+	if len(l.Services) == 0 {
+		return nil, fmt.Errorf("no service configured for tcp listener on port %d", l.Port)
+	}
+
+	cfg := &envoy_tcp_v3.TcpProxy{
+		StatPrefix: ingressListenerName(l.Port),
+		ClusterSpecifier: &envoy_tcp_v3.TcpProxy_Cluster{
+			Cluster: l.Services[0].Name,
+		},
+	}
+
+	any, err := ptypes.MarshalAny(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &envoy_listener_v3.Filter{
+		Name:       "envoy.filters.network.tcp_proxy",
+		ConfigType: &envoy_listener_v3.Filter_TypedConfig{TypedConfig: any},
+	}, nil
+}
+
+// makeIngressHTTPFilter builds an HTTP connection manager filter for an
+// HTTP-like ingress listener (http, http2 or grpc). http2 and grpc get an
+// explicit HTTP/2 codec instead of the protocol-sniffing AUTO codec used for
+// plain http, and grpc additionally gets the gRPC bridge and stats filters
+// ahead of the router so that gRPC status/trailers are handled correctly.
+func makeIngressHTTPFilter(l structs.IngressListener) (*envoy_listener_v3.Filter, error) {
+	hcm := &envoy_http_v3.HttpConnectionManager{
+		StatPrefix: ingressListenerName(l.Port),
+		RouteSpecifier: &envoy_http_v3.HttpConnectionManager_Rds{
+			Rds: &envoy_http_v3.Rds{
+				RouteConfigName: ingressRouteConfigName(l.Port),
+				ConfigSource: &envoy_core_v3.ConfigSource{
+					ResourceApiVersion: envoy_core_v3.ApiVersion_V3,
+					ConfigSourceSpecifier: &envoy_core_v3.ConfigSource_Ads{
+						Ads: &envoy_core_v3.AggregatedConfigSource{},
+					},
+				},
+			},
+		},
+	}
+
+	switch l.Protocol {
+	case "http2", "grpc":
+		hcm.CodecType = envoy_http_v3.HttpConnectionManager_HTTP2
+		hcm.Http2ProtocolOptions = &envoy_core_v3.Http2ProtocolOptions{}
+	default:
+		hcm.CodecType = envoy_http_v3.HttpConnectionManager_AUTO
+	}
+
+	if l.Protocol == "grpc" {
+		hcm.HttpFilters = append(hcm.HttpFilters,
+			&envoy_http_v3.HttpFilter{Name: "envoy.filters.http.grpc_http1_bridge"},
+			&envoy_http_v3.HttpFilter{Name: "envoy.filters.http.grpc_stats"},
+		)
+	}
+	hcm.HttpFilters = append(hcm.HttpFilters, &envoy_http_v3.HttpFilter{Name: "envoy.filters.http.router"})
+
+	any, err := ptypes.MarshalAny(hcm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &envoy_listener_v3.Filter{
+		Name:       "envoy.filters.network.http_connection_manager",
+		ConfigType: &envoy_listener_v3.Filter_TypedConfig{TypedConfig: any},
+	}, nil
+}
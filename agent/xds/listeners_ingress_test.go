@@ -0,0 +1,64 @@
+package xds
+
+import (
+	"testing"
+
+	envoy_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestMakeIngressHTTPFilter_Codec(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name              string
+		protocol          string
+		expectCodec       envoy_http_v3.HttpConnectionManager_CodecType
+		expectGRPCFilters bool
+	}{
+		{name: "http uses auto codec", protocol: "http", expectCodec: envoy_http_v3.HttpConnectionManager_AUTO},
+		{name: "http2 uses explicit http2 codec", protocol: "http2", expectCodec: envoy_http_v3.HttpConnectionManager_HTTP2},
+		{name: "grpc uses explicit http2 codec and grpc filters", protocol: "grpc", expectCodec: envoy_http_v3.HttpConnectionManager_HTTP2, expectGRPCFilters: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := structs.IngressListener{Port: 8080, Protocol: tc.protocol}
+			filter, err := makeIngressHTTPFilter(l)
+			require.NoError(t, err)
+
+			var hcm envoy_http_v3.HttpConnectionManager
+			require.NoError(t, ptypes.UnmarshalAny(filter.GetTypedConfig(), &hcm))
+			require.Equal(t, tc.expectCodec, hcm.CodecType)
+
+			var sawGRPCStats bool
+			for _, f := range hcm.HttpFilters {
+				if f.Name == "envoy.filters.http.grpc_stats" {
+					sawGRPCStats = true
+				}
+			}
+			require.Equal(t, tc.expectGRPCFilters, sawGRPCStats)
+			require.Equal(t, "envoy.filters.http.router", hcm.HttpFilters[len(hcm.HttpFilters)-1].Name)
+		})
+	}
+}
+
+func TestMakeIngressTCPFilter(t *testing.T) {
+	t.Parallel()
+
+	l := structs.IngressListener{
+		Port:     8080,
+		Protocol: "tcp",
+		Services: []structs.IngressService{{Name: "db"}},
+	}
+
+	filter, err := makeIngressTCPFilter(l)
+	require.NoError(t, err)
+	require.Equal(t, "envoy.filters.network.tcp_proxy", filter.Name)
+}
@@ -0,0 +1,191 @@
+package xds
+
+import (
+	"fmt"
+
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_matcher_v3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// makeIngressRouteConfig builds the RouteConfiguration an HTTP-like ingress
+// listener fetches over RDS, with one virtual host per configured service.
+func makeIngressRouteConfig(l structs.IngressListener) (*envoy_route_v3.RouteConfiguration, error) {
+	cfg := &envoy_route_v3.RouteConfiguration{
+		Name: ingressRouteConfigName(l.Port),
+	}
+
+	for _, s := range l.Services {
+		vhost, err := makeIngressVirtualHost(s)
+		if err != nil {
+			return nil, err
+		}
+		cfg.VirtualHosts = append(cfg.VirtualHosts, vhost)
+	}
+
+	return cfg, nil
+}
+
+// makeIngressVirtualHost builds the virtual host for a single configured
+// service. A service's Hosts become the virtual host's Domains so Envoy
+// dispatches incoming requests to the right upstream by :authority/Host
+// header; a service with no Hosts configured falls back to "*", matching
+// any request not claimed by a more specific virtual host.
+//
+// The service's Routes are translated into explicit Envoy routes ordered
+// ahead of the catch-all default route, so that the most specific matches
+// (as declared) are tried first and anything left over still reaches the
+// service itself.
+func makeIngressVirtualHost(s structs.IngressService) (*envoy_route_v3.VirtualHost, error) {
+	domains := s.Hosts
+	if len(domains) == 0 {
+		domains = []string{"*"}
+	}
+
+	routes, err := makeIngressServiceRoutes(s)
+	if err != nil {
+		return nil, err
+	}
+	routes = append(routes, makeIngressDefaultRoute(s.Name))
+
+	return &envoy_route_v3.VirtualHost{
+		Name:    s.Name,
+		Domains: domains,
+		Routes:  routes,
+	}, nil
+}
+
+// makeIngressServiceRoutes builds one Envoy route per configured
+// IngressServiceRoute, each forwarding to the service named in the route
+// rather than the virtual host's own service.
+func makeIngressServiceRoutes(s structs.IngressService) ([]*envoy_route_v3.Route, error) {
+	var routes []*envoy_route_v3.Route
+	for _, r := range s.Routes {
+		match, err := makeIngressRouteMatch(r.Match)
+		if err != nil {
+			return nil, fmt.Errorf("route for service %q: %v", r.Name, err)
+		}
+
+		clusterName := r.Name
+		if len(r.Match.Methods) == 0 {
+			routes = append(routes, &envoy_route_v3.Route{
+				Match:  match,
+				Action: makeIngressRouteAction(clusterName),
+			})
+			continue
+		}
+
+		// Envoy's RouteMatch only supports a single HeaderMatcher per
+		// header name, so a route that applies to multiple methods is
+		// expanded into one route per method, each with its own
+		// :method header matcher.
+		for _, method := range r.Match.Methods {
+			methodMatch := proto.Clone(match).(*envoy_route_v3.RouteMatch)
+			methodMatch.Headers = append(methodMatch.Headers, makeIngressMethodHeaderMatcher(method))
+			routes = append(routes, &envoy_route_v3.Route{
+				Match:  methodMatch,
+				Action: makeIngressRouteAction(clusterName),
+			})
+		}
+	}
+	return routes, nil
+}
+
+// makeIngressRouteMatch translates an IngressServiceRouteMatch's path and
+// header conditions into an Envoy RouteMatch. Method matching is handled
+// separately by makeIngressServiceRoutes since Envoy expresses it as a
+// :method header matcher per route rather than as part of the match itself.
+func makeIngressRouteMatch(m structs.IngressServiceRouteMatch) (*envoy_route_v3.RouteMatch, error) {
+	match := &envoy_route_v3.RouteMatch{}
+
+	switch {
+	case m.PathExact != "":
+		match.PathSpecifier = &envoy_route_v3.RouteMatch_Path{Path: m.PathExact}
+	case m.PathPrefix != "":
+		match.PathSpecifier = &envoy_route_v3.RouteMatch_Prefix{Prefix: m.PathPrefix}
+	case m.PathRegex != "":
+		match.PathSpecifier = &envoy_route_v3.RouteMatch_SafeRegex{
+			SafeRegex: makeEnvoyRegexMatcher(m.PathRegex),
+		}
+	default:
+		match.PathSpecifier = &envoy_route_v3.RouteMatch_Prefix{Prefix: "/"}
+	}
+
+	for _, h := range m.Header {
+		headerMatch, err := makeIngressHeaderMatcher(h)
+		if err != nil {
+			return nil, err
+		}
+		match.Headers = append(match.Headers, headerMatch)
+	}
+
+	return match, nil
+}
+
+// makeIngressHeaderMatcher translates a single IngressServiceRouteHeader
+// into an Envoy HeaderMatcher. Exactly one of Present, Exact, Prefix,
+// Suffix or Regex is set, as enforced by IngressGatewayConfigEntry.Validate.
+func makeIngressHeaderMatcher(h structs.IngressServiceRouteHeader) (*envoy_route_v3.HeaderMatcher, error) {
+	matcher := &envoy_route_v3.HeaderMatcher{
+		Name:        h.Name,
+		InvertMatch: h.Invert,
+	}
+
+	switch {
+	case h.Present:
+		matcher.HeaderMatchSpecifier = &envoy_route_v3.HeaderMatcher_PresentMatch{PresentMatch: true}
+	case h.Exact != "":
+		matcher.HeaderMatchSpecifier = &envoy_route_v3.HeaderMatcher_ExactMatch{ExactMatch: h.Exact}
+	case h.Prefix != "":
+		matcher.HeaderMatchSpecifier = &envoy_route_v3.HeaderMatcher_PrefixMatch{PrefixMatch: h.Prefix}
+	case h.Suffix != "":
+		matcher.HeaderMatchSpecifier = &envoy_route_v3.HeaderMatcher_SuffixMatch{SuffixMatch: h.Suffix}
+	case h.Regex != "":
+		matcher.HeaderMatchSpecifier = &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
+			SafeRegexMatch: makeEnvoyRegexMatcher(h.Regex),
+		}
+	default:
+		return nil, fmt.Errorf("header matcher for %q doesn't set Present, Exact, Prefix, Suffix or Regex", h.Name)
+	}
+
+	return matcher, nil
+}
+
+// makeIngressMethodHeaderMatcher builds the :method pseudo-header matcher
+// used to restrict a route to a single HTTP method.
+func makeIngressMethodHeaderMatcher(method string) *envoy_route_v3.HeaderMatcher {
+	return &envoy_route_v3.HeaderMatcher{
+		Name:                 ":method",
+		HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_ExactMatch{ExactMatch: method},
+	}
+}
+
+func makeEnvoyRegexMatcher(regex string) *envoy_matcher_v3.RegexMatcher {
+	return &envoy_matcher_v3.RegexMatcher{
+		EngineType: &envoy_matcher_v3.RegexMatcher_GoogleRe2{
+			GoogleRe2: &envoy_matcher_v3.RegexMatcher_GoogleRE2{},
+		},
+		Regex: regex,
+	}
+}
+
+func makeIngressRouteAction(clusterName string) *envoy_route_v3.Route_Route {
+	return &envoy_route_v3.Route_Route{
+		Route: &envoy_route_v3.RouteAction{
+			ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{Cluster: clusterName},
+		},
+	}
+}
+
+// makeIngressDefaultRoute builds a catch-all route that forwards every
+// request on a virtual host directly to serviceName.
+func makeIngressDefaultRoute(serviceName string) *envoy_route_v3.Route {
+	return &envoy_route_v3.Route{
+		Match: &envoy_route_v3.RouteMatch{
+			PathSpecifier: &envoy_route_v3.RouteMatch_Prefix{Prefix: "/"},
+		},
+		Action: makeIngressRouteAction(serviceName),
+	}
+}
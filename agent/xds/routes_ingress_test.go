@@ -0,0 +1,112 @@
+package xds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestMakeIngressRouteConfig_VirtualHostDomains(t *testing.T) {
+	t.Parallel()
+
+	l := structs.IngressListener{
+		Port: 8080,
+		Services: []structs.IngressService{
+			{Name: "web", Hosts: []string{"web.example.com", "www.example.com"}},
+			{Name: "api"},
+		},
+	}
+
+	cfg, err := makeIngressRouteConfig(l)
+	require.NoError(t, err)
+	require.Equal(t, ingressRouteConfigName(8080), cfg.Name)
+	require.Len(t, cfg.VirtualHosts, 2)
+
+	require.Equal(t, "web", cfg.VirtualHosts[0].Name)
+	require.Equal(t, []string{"web.example.com", "www.example.com"}, cfg.VirtualHosts[0].Domains)
+
+	require.Equal(t, "api", cfg.VirtualHosts[1].Name)
+	require.Equal(t, []string{"*"}, cfg.VirtualHosts[1].Domains)
+}
+
+func TestMakeIngressDefaultRoute(t *testing.T) {
+	t.Parallel()
+
+	route := makeIngressDefaultRoute("web")
+	require.Equal(t, "/", route.Match.GetPrefix())
+	require.Equal(t, "web", route.GetRoute().GetCluster())
+}
+
+func TestMakeIngressServiceRoutes(t *testing.T) {
+	t.Parallel()
+
+	s := structs.IngressService{
+		Name: "web",
+		Routes: []structs.IngressServiceRoute{
+			{
+				Name: "web-admin",
+				Match: structs.IngressServiceRouteMatch{
+					PathPrefix: "/admin",
+					Header: []structs.IngressServiceRouteHeader{
+						{Name: "x-debug", Present: true},
+					},
+				},
+			},
+			{
+				Name: "web-write",
+				Match: structs.IngressServiceRouteMatch{
+					PathExact: "/widgets",
+					Methods:   []string{"PUT", "POST"},
+				},
+			},
+		},
+	}
+
+	routes, err := makeIngressServiceRoutes(s)
+	require.NoError(t, err)
+	// One route for web-admin, plus one per method for web-write.
+	require.Len(t, routes, 3)
+
+	require.Equal(t, "/admin", routes[0].Match.GetPrefix())
+	require.Equal(t, "web-admin", routes[0].GetRoute().GetCluster())
+	require.Len(t, routes[0].Match.Headers, 1)
+	require.Equal(t, "x-debug", routes[0].Match.Headers[0].Name)
+	require.True(t, routes[0].Match.Headers[0].GetPresentMatch())
+
+	for _, r := range routes[1:] {
+		require.Equal(t, "/widgets", r.Match.GetPath())
+		require.Equal(t, "web-write", r.GetRoute().GetCluster())
+		require.Len(t, r.Match.Headers, 1)
+		require.Equal(t, ":method", r.Match.Headers[0].Name)
+	}
+	require.Equal(t, "PUT", routes[1].Match.Headers[0].GetExactMatch())
+	require.Equal(t, "POST", routes[2].Match.Headers[0].GetExactMatch())
+}
+
+func TestMakeIngressHeaderMatcher(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		header structs.IngressServiceRouteHeader
+	}{
+		{"present", structs.IngressServiceRouteHeader{Name: "x-a", Present: true}},
+		{"exact", structs.IngressServiceRouteHeader{Name: "x-a", Exact: "v"}},
+		{"prefix", structs.IngressServiceRouteHeader{Name: "x-a", Prefix: "v"}},
+		{"suffix", structs.IngressServiceRouteHeader{Name: "x-a", Suffix: "v"}},
+		{"regex", structs.IngressServiceRouteHeader{Name: "x-a", Regex: "v.*"}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			matcher, err := makeIngressHeaderMatcher(tc.header)
+			require.NoError(t, err)
+			require.Equal(t, tc.header.Name, matcher.Name)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package xds
+
+import (
+	"testing"
+
+	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestApplyIngressTLS(t *testing.T) {
+	t.Parallel()
+
+	t.Run("disabled leaves the chain untouched", func(t *testing.T) {
+		t.Parallel()
+
+		entry := &structs.IngressGatewayConfigEntry{}
+		chain := &envoy_listener_v3.FilterChain{}
+		require.NoError(t, applyIngressTLS(chain, entry, structs.IngressListener{Port: 8080}))
+		require.Nil(t, chain.TransportSocket)
+		require.Nil(t, chain.FilterChainMatch)
+	})
+
+	t.Run("inline cert/key with SNI", func(t *testing.T) {
+		t.Parallel()
+
+		entry := &structs.IngressGatewayConfigEntry{}
+		l := structs.IngressListener{
+			Port: 8080,
+			TLS: &structs.IngressGatewayTLSConfig{
+				Enabled: true,
+				Cert:    "cert-pem",
+				Key:     "key-pem",
+				SNI:     []string{"foo.example.com"},
+			},
+		}
+
+		chain := &envoy_listener_v3.FilterChain{}
+		require.NoError(t, applyIngressTLS(chain, entry, l))
+		require.NotNil(t, chain.TransportSocket)
+		require.Equal(t, []string{"foo.example.com"}, chain.FilterChainMatch.ServerNames)
+
+		var tlsContext envoy_tls_v3.DownstreamTlsContext
+		require.NoError(t, ptypes.UnmarshalAny(chain.TransportSocket.GetTypedConfig(), &tlsContext))
+		certs := tlsContext.CommonTlsContext.TlsCertificates
+		require.Len(t, certs, 1)
+		require.Equal(t, "cert-pem", certs[0].CertificateChain.GetInlineString())
+		require.Equal(t, "key-pem", certs[0].PrivateKey.GetInlineString())
+	})
+
+	t.Run("file-referenced cert/key", func(t *testing.T) {
+		t.Parallel()
+
+		entry := &structs.IngressGatewayConfigEntry{}
+		l := structs.IngressListener{
+			Port: 8080,
+			TLS: &structs.IngressGatewayTLSConfig{
+				Enabled:  true,
+				CertFile: "/etc/certs/leaf.pem",
+				KeyFile:  "/etc/certs/leaf-key.pem",
+			},
+		}
+
+		chain := &envoy_listener_v3.FilterChain{}
+		require.NoError(t, applyIngressTLS(chain, entry, l))
+
+		var tlsContext envoy_tls_v3.DownstreamTlsContext
+		require.NoError(t, ptypes.UnmarshalAny(chain.TransportSocket.GetTypedConfig(), &tlsContext))
+		certs := tlsContext.CommonTlsContext.TlsCertificates
+		require.Len(t, certs, 1)
+		require.Equal(t, "/etc/certs/leaf.pem", certs[0].CertificateChain.GetFilename())
+		require.Equal(t, "/etc/certs/leaf-key.pem", certs[0].PrivateKey.GetFilename())
+	})
+
+	t.Run("enabled with no cert material errors instead of emitting an empty cert", func(t *testing.T) {
+		t.Parallel()
+
+		entry := &structs.IngressGatewayConfigEntry{}
+		l := structs.IngressListener{
+			Port: 8080,
+			TLS:  &structs.IngressGatewayTLSConfig{Enabled: true},
+		}
+
+		chain := &envoy_listener_v3.FilterChain{}
+		err := applyIngressTLS(chain, entry, l)
+		require.Error(t, err)
+		require.Nil(t, chain.TransportSocket)
+	})
+
+	t.Run("falls back to gateway-wide TLS config", func(t *testing.T) {
+		t.Parallel()
+
+		entry := &structs.IngressGatewayConfigEntry{
+			TLS: structs.IngressGatewayTLSConfig{Enabled: true, Cert: "cert-pem", Key: "key-pem"},
+		}
+		chain := &envoy_listener_v3.FilterChain{}
+		require.NoError(t, applyIngressTLS(chain, entry, structs.IngressListener{Port: 8080}))
+		require.NotNil(t, chain.TransportSocket)
+	})
+}